@@ -0,0 +1,123 @@
+// Package config 从 conf/config.yaml 加载配置，支持按 APP_ENV 选择 profile、
+// 用 GYMO_ 前缀的环境变量覆盖任意字段，并在文件变化时自动热重载可安全热更的部分
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Server   Server   `mapstructure:"server"`
+	Database Database `mapstructure:"database"`
+	JWT      JWT      `mapstructure:"jwt"`
+	Mailer   Mailer   `mapstructure:"mailer"`
+	App      App      `mapstructure:"app"`
+
+	mu      sync.RWMutex
+	runtime Runtime
+}
+
+type Server struct {
+	Port string `mapstructure:"port"`
+	Mode string `mapstructure:"mode"`
+}
+
+type Database struct {
+	Driver       string `mapstructure:"driver"`
+	DSN          string `mapstructure:"dsn"`
+	MaxOpenConns int    `mapstructure:"max_open_conns"`
+}
+
+type JWT struct {
+	AccessSecret string        `mapstructure:"access_secret"`
+	AccessTTL    time.Duration `mapstructure:"access_ttl"`
+	RefreshTTL   time.Duration `mapstructure:"refresh_ttl"`
+}
+
+// Mailer 选择账号邮件（验证、重置密码）的投递方式
+// Driver 为 "dev" 时只把邮件内容打印到日志，不做真实投递
+type Mailer struct {
+	Driver   string `mapstructure:"driver"`
+	From     string `mapstructure:"from"`
+	SMTPHost string `mapstructure:"smtp_host"`
+	SMTPPort string `mapstructure:"smtp_port"`
+	SMTPUser string `mapstructure:"smtp_user"`
+	SMTPPass string `mapstructure:"smtp_pass"`
+}
+
+// App 是拼接邮件里验证 / 重置链接所需的对外可访问地址
+type App struct {
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// Runtime 里的字段允许在不重启进程的情况下通过编辑 conf/config.yaml 生效，
+// 具体消费方见 middlewares.RateLimit
+type Runtime struct {
+	RateLimitRPS float64 `mapstructure:"rate_limit_rps"`
+}
+
+// Load 读取配置文件并开始监听其变化
+// APP_ENV=prod 会改为加载 conf/config.prod.yaml，环境变量例如 GYMO_DATABASE_DSN
+// 会覆盖 database.dsn
+func Load() (*Config, error) {
+	v := viper.New()
+	v.AddConfigPath("./conf")
+	v.SetConfigType("yaml")
+	v.SetConfigName(configName())
+
+	v.SetEnvPrefix("GYMO")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("config: read config: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("config: unmarshal config: %w", err)
+	}
+	cfg.reloadRuntime(v)
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		log.Println("config: reloaded", e.Name)
+		cfg.reloadRuntime(v)
+	})
+	v.WatchConfig()
+
+	return cfg, nil
+}
+
+func configName() string {
+	if env := os.Getenv("APP_ENV"); env != "" {
+		return "config." + env
+	}
+	return "config"
+}
+
+func (c *Config) reloadRuntime(v *viper.Viper) {
+	var runtime Runtime
+	if err := v.UnmarshalKey("runtime", &runtime); err != nil {
+		log.Println("config: reload runtime:", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.runtime = runtime
+	c.mu.Unlock()
+}
+
+// RateLimitRPS 返回当前生效的限流阈值，随配置文件热更新
+func (c *Config) RateLimitRPS() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.runtime.RateLimitRPS
+}
@@ -1,30 +1,43 @@
 package controllers
 
 import (
-	"fmt"
-	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"gorm.io/gorm"
 
-	"rua.plus/gymo/models"
+	contactsservice "rua.plus/gymo/service/contacts"
 	"rua.plus/gymo/utils"
 )
 
 type Contacts struct {
-	Db *gorm.DB
+	Service contactsservice.Service
 }
 
 type MakeFirendJson struct {
 	Uid uint `json:"uid" binding:"required"`
 }
 
+// 分页查询参数
+type PageQuery struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=20"`
+}
+
+func (q PageQuery) toPage() contactsservice.Page {
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+	size := q.PageSize
+	if size < 1 || size > 100 {
+		size = 20
+	}
+	return contactsservice.Page{Offset: (page - 1) * size, Limit: size}
+}
+
 // 向指定的用户发送好友请求
-// 发送后将保存到 `firend_request` 表中
-// 同时向对方发送通知
-// TODO: 给对方用户发送通知
 func (contacts Contacts) MakeFirend(c *gin.Context) {
 	// response
 	resp := &utils.BasicRes{}
@@ -32,112 +45,154 @@ func (contacts Contacts) MakeFirend(c *gin.Context) {
 
 	var info MakeFirendJson
 	if err := c.ShouldBindWith(&info, binding.JSON); err != nil {
-		utils.FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+		utils.RenderBindError(c, resp, err)
 		return
 	}
 
-	// check is self
-	if info.Uid == u.UID {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			"cannot make firend with self",
-		)
+	req, err := contacts.Service.MakeFirend(u.UID, info.Uid)
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
-	// find target user
-	firend := &models.User{}
-	dbRes := contacts.Db.Model(firend).Find(firend, "uid = ?", info.Uid)
-	if dbRes.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			dbRes.Error.Error(),
-		)
+	resp.Status = "ok"
+	resp.Data = req
+	c.JSON(http.StatusOK, resp)
+}
+
+// IncomingRequests 分页列出发给当前用户的好友请求
+func (contacts Contacts) IncomingRequests(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	var query PageQuery
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.RenderBindError(c, resp, err)
 		return
 	}
-	if dbRes.RowsAffected == 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			"target user not exist",
-		)
+
+	requests, err := contacts.Service.ListIncoming(u.UID, query.toPage())
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
-	// check is already in contect
-	contact := &models.Contact{}
-	dbRes = contacts.Db.Model(contact).
-		Find(contact, "user_uid = ? AND firend_uid = ?", u.UID, info.Uid)
-	if dbRes.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			dbRes.Error.Error(),
-		)
+	resp.Status = "ok"
+	resp.Data = requests
+	c.JSON(http.StatusOK, resp)
+}
+
+// OutgoingRequests 分页列出当前用户发出的好友请求
+func (contacts Contacts) OutgoingRequests(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	var query PageQuery
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.RenderBindError(c, resp, err)
 		return
 	}
-	if dbRes.RowsAffected != 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			"target user is already firend",
-		)
+
+	requests, err := contacts.Service.ListOutgoing(u.UID, query.toPage())
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
-	// save to request
-	firendReq := &models.FirendRequest{}
-	dbRes = contacts.Db.Model(firendReq).
-		Find(firendReq, "from_user_uid = ? AND to_user_uid = ?", u.UID, info.Uid)
-	if dbRes.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			dbRes.Error.Error(),
-		)
+	resp.Status = "ok"
+	resp.Data = requests
+	c.JSON(http.StatusOK, resp)
+}
+
+func requestIDFromParam(c *gin.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	return uint(id), err
+}
+
+// AcceptRequest 同意好友请求
+func (contacts Contacts) AcceptRequest(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	id, err := requestIDFromParam(c)
+	if err != nil {
+		utils.FailedAndReturn(c, resp, http.StatusBadRequest, "invalid request id")
 		return
 	}
-	if dbRes.RowsAffected != 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			fmt.Sprintf("already sent a request to user %d", firend.UID),
-		)
+
+	req, err := contacts.Service.Accept(id, u.UID)
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
-	firendReq.FromUserUID = u.UID
-	firendReq.ToUserUID = firend.UID
-	contacts.Db.Save(firendReq)
-
-	// save
-	/* contact.UserUID = u.UID */
-	/* contact.Firend = firend.UID */
-	/* dbRes = contacts.Db.Save(contact) */
-	/* if dbRes.Error != nil { */
-	/* 	resp.Status = "error" */
-	/* 	resp.Message = dbRes.Error.Error() */
-	/* 	c.JSON(http.StatusInternalServerError, resp) */
-	/* 	return */
-	/* } */
 
 	resp.Status = "ok"
-	resp.Message = ""
+	resp.Data = req
 	c.JSON(http.StatusOK, resp)
 }
 
-func (contacts Contacts) CheckRequest(c *gin.Context) {
-	// response
+// RejectRequest 拒绝好友请求
+func (contacts Contacts) RejectRequest(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	id, err := requestIDFromParam(c)
+	if err != nil {
+		utils.FailedAndReturn(c, resp, http.StatusBadRequest, "invalid request id")
+		return
+	}
+
+	req, err := contacts.Service.Reject(id, u.UID)
+	if err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	resp.Data = req
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelRequest 发送方撤回尚未处理的好友请求
+func (contacts Contacts) CancelRequest(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	id, err := requestIDFromParam(c)
+	if err != nil {
+		utils.FailedAndReturn(c, resp, http.StatusBadRequest, "invalid request id")
+		return
+	}
+
+	req, err := contacts.Service.Cancel(id, u.UID)
+	if err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	resp.Data = req
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListContacts 分页列出当前用户的好友，附带基本的用户信息
+func (contacts Contacts) ListContacts(c *gin.Context) {
 	resp := &utils.BasicRes{}
 	u := utils.GetContextUser(c, resp)
 
-	log.Println(u)
-}
\ No newline at end of file
+	var query PageQuery
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.RenderBindError(c, resp, err)
+		return
+	}
+
+	friends, err := contacts.Service.ListContacts(u.UID, query.toPage())
+	if err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	resp.Data = friends
+	c.JSON(http.StatusOK, resp)
+}
@@ -3,18 +3,17 @@ package controllers
 import (
 	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"gorm.io/gorm"
 
 	"rua.plus/gymo/models"
+	userservice "rua.plus/gymo/service/user"
 	"rua.plus/gymo/utils"
 )
 
 type User struct {
-	Db *gorm.DB
+	Service userservice.Service
 }
 
 // 查询用户
@@ -30,33 +29,13 @@ func (user User) GetUser(c *gin.Context) {
 
 	var userInfo UserQuery
 	if err := c.ShouldBindWith(&userInfo, binding.Query); err != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusBadRequest,
-			err.Error(),
-		)
+		utils.RenderBindError(c, resp, err)
 		return
 	}
 
-	u := &models.User{}
-	res := user.Db.Model(u).Find(u, "email = ?", userInfo.Email)
-	if res.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			res.Error.Error(),
-		)
-		return
-	}
-	if res.RowsAffected == 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			"user not exist",
-		)
+	u, err := user.Service.GetByEmail(userInfo.Email)
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
@@ -67,8 +46,8 @@ func (user User) GetUser(c *gin.Context) {
 
 // 用户注册
 type UserJson struct {
-	Username    string `json:"username"    binding:"required"`
-	Password    string `json:"password"    binding:"required"`
+	Username    string `json:"username"    binding:"required,username"`
+	Password    string `json:"password"    binding:"required,password"`
 	Email       string `json:"email"       binding:"required,email"`
 	Description string `json:"description"`
 	Gender      int8   `json:"gender"`
@@ -82,7 +61,7 @@ func (user User) AddUser(c *gin.Context) {
 
 	var userInfo UserJson
 	if err := c.ShouldBindWith(&userInfo, binding.JSON); err != nil {
-		utils.FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+		utils.RenderBindError(c, resp, err)
 		return
 	}
 
@@ -94,23 +73,8 @@ func (user User) AddUser(c *gin.Context) {
 		Gender:      userInfo.Gender,
 	}
 
-	res := user.Db.Model(u).Where("email = ?", u.Email).FirstOrCreate(&u)
-	if res.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			res.Error.Error(),
-		)
-		return
-	}
-	if res.RowsAffected == 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusConflict,
-			"user already exist",
-		)
+	if err := user.Service.Register(u); err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
@@ -120,127 +84,144 @@ func (user User) AddUser(c *gin.Context) {
 }
 
 type UserModify struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Email    string `json:"email"    binding:"email"`
+	Username string `json:"username" binding:"omitempty,username"`
+	Password string `json:"password" binding:"omitempty,password"`
+	Email    string `json:"email"    binding:"omitempty,email"`
 }
 
 func (user User) ModifyUser(c *gin.Context) {
 	// response
 	resp := &utils.BasicRes{}
 
-	u := utils.GetContextUser(c, resp)
+	ctxUser := utils.GetContextUser(c, resp)
 
 	userInfo := &UserModify{}
 	if err := c.ShouldBindWith(&userInfo, binding.JSON); err != nil {
-		utils.FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+		utils.RenderBindError(c, resp, err)
 		return
 	}
-	if userInfo.Username != "" {
-		u.Username = userInfo.Username
-	}
-	if userInfo.Email != "" {
-		u.Email = userInfo.Email
-	}
-	if userInfo.Password != "" {
-		u.Password = userInfo.Password
-		u.HashPassword()
-	}
 
-	res := user.Db.Save(u)
-	if res.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			res.Error.Error(),
-		)
+	updated, err := user.Service.Modify(ctxUser.UID, userservice.Patch{
+		Username: userInfo.Username,
+		Email:    userInfo.Email,
+		Password: userInfo.Password,
+	})
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
 	resp.Status = "ok"
-	resp.Data = u
+	resp.Data = updated
 	c.JSON(http.StatusOK, resp)
-
 }
 
 // 用户登录 json
 type UserLogin struct {
 	Email    string `json:"email"    binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Password string `json:"password" binding:"required,password"`
+	// Device 是一个可读的设备标签，用于在 refresh_tokens 中区分会话，便于单独吊销
+	Device string `json:"device"`
 }
 type LoginResponse struct {
 	*models.User
-	Token string `json:"token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 // 用户登录
 // 仅支持 json
+// 登录会同时签发一个短期的 access token 和一个长期的 refresh token，
+// 二者互相独立，因此同一账号可以在多台设备上同时保持登录
 func (user User) Login(c *gin.Context) {
 	// response
 	resp := &utils.BasicRes{}
 
 	var userInfo UserLogin
 	if err := c.ShouldBindWith(&userInfo, binding.JSON); err != nil {
-		utils.FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+		utils.RenderBindError(c, resp, err)
 		return
 	}
 
-	// query the user
-	u := &models.User{}
-	dbRes := user.Db.Model(&models.User{}).Find(&u, "email = ?", userInfo.Email)
-	if dbRes.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			dbRes.Error.Error(),
-		)
-		return
+	device := userInfo.Device
+	if device == "" {
+		device = c.Request.UserAgent()
 	}
-	if dbRes.RowsAffected == 0 {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnprocessableEntity,
-			"user not exist",
-		)
+
+	session, err := user.Service.Login(userInfo.Email, userInfo.Password, device)
+	if err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 
-	// check the password
-	if err := models.CheckPasswordHash(userInfo.Password, u.Password); err != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusUnauthorized,
-			"password not correct",
-		)
+	resp.Status = "ok"
+	resp.Data = &LoginResponse{
+		User:         session.User,
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RefreshJson 换取新 access token 所需的 refresh token
+type RefreshJson struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh 用一个有效且未吊销的 refresh token 换取新的 access token
+func (user User) Refresh(c *gin.Context) {
+	resp := &utils.BasicRes{}
+
+	var body RefreshJson
+	if err := c.ShouldBindWith(&body, binding.JSON); err != nil {
+		utils.RenderBindError(c, resp, err)
 		return
 	}
 
-	// generate token
-	lastLogin := time.Now().Unix()
-	token, err := utils.GenerateToken(int(u.ID), lastLogin)
+	session, err := user.Service.Refresh(body.RefreshToken)
 	if err != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			err.Error(),
-		)
+		utils.RenderError(c, resp, err)
 		return
 	}
 
-	// update last login
-	u.LastLogin = lastLogin
-	user.Db.Save(u)
-
 	resp.Status = "ok"
 	resp.Data = &LoginResponse{
-		User:  u,
-		Token: token,
+		User:        session.User,
+		AccessToken: session.AccessToken,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout 吊销当前这一个 refresh token，即退出当前设备
+func (user User) Logout(c *gin.Context) {
+	resp := &utils.BasicRes{}
+
+	var body RefreshJson
+	if err := c.ShouldBindWith(&body, binding.JSON); err != nil {
+		utils.RenderBindError(c, resp, err)
+		return
+	}
+
+	if err := user.Service.Logout(body.RefreshToken); err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	c.JSON(http.StatusOK, resp)
+}
+
+// LogoutAll 吊销当前用户的所有 refresh token，即退出全部设备
+func (user User) LogoutAll(c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	if err := user.Service.LogoutAll(u.UID); err != nil {
+		utils.RenderError(c, resp, err)
+		return
 	}
+
+	resp.Status = "ok"
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -257,20 +238,85 @@ func (user User) UserSelf(c *gin.Context) {
 	return
 }
 
+// VerifyQuery 邮箱验证链接携带的查询参数
+type VerifyQuery struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// VerifyEmail 通过注册邮件里的链接完成邮箱验证
+func (user User) VerifyEmail(c *gin.Context) {
+	resp := &utils.BasicRes{}
+
+	var query VerifyQuery
+	if err := c.ShouldBindWith(&query, binding.Query); err != nil {
+		utils.RenderBindError(c, resp, err)
+		return
+	}
+
+	if err := user.Service.VerifyEmail(query.Token); err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	c.JSON(http.StatusOK, resp)
+}
+
+// ForgotPasswordJson 只需要邮箱；无论邮箱是否存在都返回 200，避免暴露账号是否已注册
+type ForgotPasswordJson struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (user User) ForgotPassword(c *gin.Context) {
+	resp := &utils.BasicRes{}
+
+	var body ForgotPasswordJson
+	if err := c.ShouldBindWith(&body, binding.JSON); err != nil {
+		utils.RenderBindError(c, resp, err)
+		return
+	}
+
+	if err := user.Service.ForgotPassword(body.Email); err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResetPasswordJson 携带重置令牌和新密码
+type ResetPasswordJson struct {
+	Token    string `json:"token"    binding:"required"`
+	Password string `json:"password" binding:"required,password"`
+}
+
+func (user User) ResetPassword(c *gin.Context) {
+	resp := &utils.BasicRes{}
+
+	var body ResetPasswordJson
+	if err := c.ShouldBindWith(&body, binding.JSON); err != nil {
+		utils.RenderBindError(c, resp, err)
+		return
+	}
+
+	if err := user.Service.ResetPassword(body.Token, body.Password); err != nil {
+		utils.RenderError(c, resp, err)
+		return
+	}
+
+	resp.Status = "ok"
+	c.JSON(http.StatusOK, resp)
+}
+
 // 删除当前用户
 func (user User) Delete(c *gin.Context) {
 	// response
 	resp := &utils.BasicRes{}
 	u := utils.GetContextUser(c, resp)
 
-	res := user.Db.Model(&models.User{}).Delete(u, "email = ?", u.Email)
-	if res.Error != nil {
-		utils.FailedAndReturn(
-			c,
-			resp,
-			http.StatusInternalServerError,
-			res.Error.Error(),
-		)
+	if err := user.Service.Delete(u); err != nil {
+		utils.RenderError(c, resp, err)
 		return
 	}
 	msg := fmt.Sprintf("account %s has been deleted", u.Email)
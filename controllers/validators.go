@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"regexp"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{3,20}$`)
+
+// init 把本包用到的自定义校验规则注册到 gin 的默认 validator 引擎上，
+// 这样 UserJson、UserModify、UserLogin 只需要在字段上声明 `binding:"password"` /
+// `binding:"username"` 就能复用
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterValidation("password", validatePassword)
+	v.RegisterValidation("username", validateUsername)
+}
+
+func validatePassword(fl validator.FieldLevel) bool {
+	return isValidPassword(fl.Field().String())
+}
+
+// isValidPassword 要求至少 8 位，并且同时包含字母和数字
+func isValidPassword(value string) bool {
+	if len(value) < 8 {
+		return false
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	return hasLetter && hasDigit
+}
+
+func validateUsername(fl validator.FieldLevel) bool {
+	return isValidUsername(fl.Field().String())
+}
+
+// isValidUsername 限制用户名只能使用字母、数字和下划线
+func isValidUsername(value string) bool {
+	return usernamePattern.MatchString(value)
+}
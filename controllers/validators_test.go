@@ -0,0 +1,33 @@
+package controllers
+
+import "testing"
+
+func TestIsValidPassword(t *testing.T) {
+	cases := map[string]bool{
+		"short1":             false, // too short
+		"alllettersnodigits": false, // no digit
+		"12345678":           false, // no letter
+		"abcd1234":           true,
+	}
+
+	for value, want := range cases {
+		if got := isValidPassword(value); got != want {
+			t.Errorf("isValidPassword(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestIsValidUsername(t *testing.T) {
+	cases := map[string]bool{
+		"ab":          false, // too short
+		"valid_user1": true,
+		"has space":   false,
+		"way-too-long-to-be-a-username-really": false,
+	}
+
+	for value, want := range cases {
+		if got := isValidUsername(value); got != want {
+			t.Errorf("isValidUsername(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package db 负责按配置建立数据库连接
+package db
+
+import (
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"rua.plus/gymo/config"
+)
+
+// Open 按配置打开一个新的数据库连接
+// 不再暴露包级别的全局 *gorm.DB，调用方（通常是 server.InitRouter）
+// 负责把返回值一路传给需要它的 repository
+func Open(cfg config.Database) (*gorm.DB, error) {
+	database, err := gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := database.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+
+	return database, nil
+}
@@ -0,0 +1,16 @@
+package mailer
+
+import "log"
+
+// DevMailer 不做任何真实投递，只把邮件内容打印到日志，
+// 方便本地开发时直接从日志里复制验证 / 重置链接
+type DevMailer struct{}
+
+func NewDevMailer() *DevMailer {
+	return &DevMailer{}
+}
+
+func (m *DevMailer) Send(to, subject, body string) error {
+	log.Printf("mailer(dev): to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,11 @@
+package mailer
+
+import "rua.plus/gymo/config"
+
+// New 按配置选择邮件投递方式；"dev" 之外的任何值都会走真实的 SMTP 投递
+func New(cfg config.Mailer) Mailer {
+	if cfg.Driver == "dev" {
+		return NewDevMailer()
+	}
+	return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.From)
+}
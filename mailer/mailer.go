@@ -0,0 +1,8 @@
+// Package mailer 把“发一封账号相关邮件”这件事从具体的投递方式中抽象出来，
+// 这样 service/user 只依赖 Mailer 接口，测试时可以换成假实现
+package mailer
+
+// Mailer 发送一封纯文本邮件，不关心邮件的业务含义
+type Mailer interface {
+	Send(to, subject, body string) error
+}
@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+)
+
+// SMTPMailer 通过标准库 net/smtp 投递邮件，用于生产环境
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+		From:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	addr := fmt.Sprintf("%s:%s", m.Host, m.Port)
+	auth := smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	msg := []byte("From: " + m.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"\r\n" + body + "\r\n")
+
+	return smtp.SendMail(addr, auth, m.From, []string{to}, msg)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log"
+
+	"rua.plus/gymo/config"
+	"rua.plus/gymo/server"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("main: load config: %v", err)
+	}
+
+	router := server.InitRouter(cfg)
+	if err := router.Run(":" + cfg.Server.Port); err != nil {
+		log.Fatalf("main: run server: %v", err)
+	}
+}
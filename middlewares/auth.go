@@ -0,0 +1,57 @@
+// Package middlewares 提供鉴权相关的 gin 中间件
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"rua.plus/gymo/config"
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/utils"
+)
+
+// TokenAuth 校验 Authorization header 里的 access token，只看签名和有效期。
+// 会话的吊销由 refresh token 表单独维护（参见 service/user），因此在其它设备登录、
+// 吊销 refresh token 乃至修改密码都不会让已签发的 access token 提前失效，它只在过期后被拒绝
+func TokenAuth(cfg config.JWT, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp := &utils.BasicRes{}
+
+		raw := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if raw == "" {
+			utils.FailedAndReturn(c, resp, http.StatusUnauthorized, "missing token")
+			c.Abort()
+			return
+		}
+
+		claims := &utils.AccessClaims{}
+		_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (any, error) {
+			return []byte(cfg.AccessSecret), nil
+		})
+		if err != nil {
+			utils.FailedAndReturn(c, resp, http.StatusUnauthorized, "invalid or expired token")
+			c.Abort()
+			return
+		}
+
+		u := &models.User{}
+		res := db.Model(u).Find(u, "id = ?", claims.UID)
+		if res.Error != nil {
+			utils.FailedAndReturn(c, resp, http.StatusInternalServerError, res.Error.Error())
+			c.Abort()
+			return
+		}
+		if res.RowsAffected == 0 {
+			utils.FailedAndReturn(c, resp, http.StatusUnauthorized, "user not exist")
+			c.Abort()
+			return
+		}
+
+		utils.SetContextUser(c, u)
+		c.Next()
+	}
+}
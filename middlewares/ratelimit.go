@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"rua.plus/gymo/config"
+	"rua.plus/gymo/utils"
+)
+
+// RateLimit 对整个进程的请求做一个简单的全局限流，阈值读自
+// config.Runtime.RateLimitRPS，在 conf/config.yaml 里改一下就会在下一个请求生效，
+// 不需要重启进程
+func RateLimit(cfg *config.Config) gin.HandlerFunc {
+	limiter := rate.NewLimiter(rate.Limit(cfg.RateLimitRPS()), burstFor(cfg.RateLimitRPS()))
+
+	return func(c *gin.Context) {
+		limit := rate.Limit(cfg.RateLimitRPS())
+		limiter.SetLimit(limit)
+		limiter.SetBurst(burstFor(cfg.RateLimitRPS()))
+
+		if !limiter.Allow() {
+			resp := &utils.BasicRes{}
+			utils.FailedAndReturn(c, resp, http.StatusTooManyRequests, "rate limit exceeded")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// burstFor 给限流器留一点突发余量，避免卡在平均速率上拒绝正常的瞬时峰值
+func burstFor(rps float64) int {
+	burst := int(rps)
+	if burst < 1 {
+		burst = 1
+	}
+	return burst
+}
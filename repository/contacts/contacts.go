@@ -0,0 +1,126 @@
+// Package contacts 封装对 `firend_request` 与 `contacts` 表的访问
+package contacts
+
+import (
+	"gorm.io/gorm"
+
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+)
+
+// Repository 返回统一的领域错误（repository.ErrNotFound 等），而不是底层的 gorm 错误
+type Repository interface {
+	FindRequestBetween(fromUID, toUID uint) (*models.FirendRequest, error)
+	FindRequestByID(id uint) (*models.FirendRequest, error)
+	CreateRequest(req *models.FirendRequest) error
+	DeleteRequest(req *models.FirendRequest) error
+	ListIncoming(uid uint, offset, limit int) ([]models.FirendRequest, error)
+	ListOutgoing(uid uint, offset, limit int) ([]models.FirendRequest, error)
+
+	FindContact(userUID, firendUID uint) (*models.Contact, error)
+	ListContacts(uid uint, offset, limit int) ([]models.User, error)
+
+	// Accept 在一个事务内为双方各自创建一条 contacts 记录并删除原始请求
+	Accept(req *models.FirendRequest) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) FindRequestBetween(fromUID, toUID uint) (*models.FirendRequest, error) {
+	req := &models.FirendRequest{}
+	res := r.db.Model(req).Find(req, "from_user_uid = ? AND to_user_uid = ?", fromUID, toUID)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return req, nil
+}
+
+func (r *gormRepository) FindRequestByID(id uint) (*models.FirendRequest, error) {
+	req := &models.FirendRequest{}
+	res := r.db.Model(req).Find(req, "id = ?", id)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return req, nil
+}
+
+func (r *gormRepository) CreateRequest(req *models.FirendRequest) error {
+	return r.db.Save(req).Error
+}
+
+func (r *gormRepository) DeleteRequest(req *models.FirendRequest) error {
+	return r.db.Delete(req).Error
+}
+
+func (r *gormRepository) ListIncoming(uid uint, offset, limit int) ([]models.FirendRequest, error) {
+	var requests []models.FirendRequest
+	res := r.db.Model(&models.FirendRequest{}).
+		Where("to_user_uid = ?", uid).
+		Offset(offset).
+		Limit(limit).
+		Find(&requests)
+	return requests, res.Error
+}
+
+func (r *gormRepository) ListOutgoing(uid uint, offset, limit int) ([]models.FirendRequest, error) {
+	var requests []models.FirendRequest
+	res := r.db.Model(&models.FirendRequest{}).
+		Where("from_user_uid = ?", uid).
+		Offset(offset).
+		Limit(limit).
+		Find(&requests)
+	return requests, res.Error
+}
+
+func (r *gormRepository) FindContact(userUID, firendUID uint) (*models.Contact, error) {
+	contact := &models.Contact{}
+	res := r.db.Model(contact).Find(contact, "user_uid = ? AND firend = ?", userUID, firendUID)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return contact, nil
+}
+
+func (r *gormRepository) ListContacts(uid uint, offset, limit int) ([]models.User, error) {
+	var friends []models.User
+	res := r.db.Model(&models.User{}).
+		Joins("JOIN contacts ON contacts.firend = users.uid").
+		Where("contacts.user_uid = ?", uid).
+		Offset(offset).
+		Limit(limit).
+		Find(&friends)
+	return friends, res.Error
+}
+
+func (r *gormRepository) Accept(req *models.FirendRequest) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.Contact{
+			UserUID: req.FromUserUID,
+			Firend:  req.ToUserUID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.Contact{
+			UserUID: req.ToUserUID,
+			Firend:  req.FromUserUID,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(req).Error
+	})
+}
@@ -0,0 +1,14 @@
+// Package repository 定义所有 repository 实现共用的领域错误
+// controller/service 层据此映射 HTTP 状态码，而不必关心具体的存储实现
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound 表示按给定条件没有查到任何记录
+	ErrNotFound = errors.New("repository: not found")
+	// ErrAlreadyExists 表示唯一性约束对应的记录已经存在
+	ErrAlreadyExists = errors.New("repository: already exists")
+	// ErrConflict 表示请求与当前状态冲突（例如对方已经是好友）
+	ErrConflict = errors.New("repository: conflict")
+)
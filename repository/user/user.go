@@ -0,0 +1,135 @@
+// Package user 封装对 `users`、`refresh_tokens` 与 `user_tokens` 表的访问
+package user
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+)
+
+// Repository 返回统一的领域错误（repository.ErrNotFound 等），而不是底层的 gorm 错误
+type Repository interface {
+	FindByEmail(email string) (*models.User, error)
+	FindByUID(uid uint) (*models.User, error)
+	Create(u *models.User) error
+	Save(u *models.User) error
+	Delete(u *models.User) error
+
+	CreateRefreshToken(rt *models.RefreshToken) error
+	// FindActiveRefreshToken 只返回未吊销且 IssuedAt 晚于 notBefore 的 token，
+	// 调用方据此把配置的 RefreshTTL 落到实处，而不只是防止 Revoked 之外的永久有效
+	FindActiveRefreshToken(tokenHash string, notBefore int64) (*models.RefreshToken, error)
+	SaveRefreshToken(rt *models.RefreshToken) error
+	RevokeAllRefreshTokens(uid uint) error
+
+	// user_tokens 表承载邮箱验证 / 密码重置等一次性令牌，按 purpose 区分用途
+	CreateUserToken(ut *models.UserToken) error
+	FindActiveUserToken(tokenHash, purpose string) (*models.UserToken, error)
+	ConsumeUserToken(ut *models.UserToken) error
+}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) FindByEmail(email string) (*models.User, error) {
+	u := &models.User{}
+	res := r.db.Model(u).Find(u, "email = ?", email)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *gormRepository) FindByUID(uid uint) (*models.User, error) {
+	u := &models.User{}
+	res := r.db.Model(u).Find(u, "uid = ?", uid)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *gormRepository) Create(u *models.User) error {
+	res := r.db.Model(u).Where("email = ?", u.Email).FirstOrCreate(u)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *gormRepository) Save(u *models.User) error {
+	return r.db.Save(u).Error
+}
+
+func (r *gormRepository) Delete(u *models.User) error {
+	return r.db.Delete(u).Error
+}
+
+func (r *gormRepository) CreateRefreshToken(rt *models.RefreshToken) error {
+	return r.db.Create(rt).Error
+}
+
+func (r *gormRepository) FindActiveRefreshToken(tokenHash string, notBefore int64) (*models.RefreshToken, error) {
+	rt := &models.RefreshToken{}
+	res := r.db.Model(rt).Find(rt, "token_hash = ? AND revoked = ? AND issued_at > ?", tokenHash, false, notBefore)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return rt, nil
+}
+
+func (r *gormRepository) SaveRefreshToken(rt *models.RefreshToken) error {
+	return r.db.Save(rt).Error
+}
+
+func (r *gormRepository) RevokeAllRefreshTokens(uid uint) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_uid = ? AND revoked = ?", uid, false).
+		Update("revoked", true).Error
+}
+
+func (r *gormRepository) CreateUserToken(ut *models.UserToken) error {
+	return r.db.Create(ut).Error
+}
+
+func (r *gormRepository) FindActiveUserToken(tokenHash, purpose string) (*models.UserToken, error) {
+	ut := &models.UserToken{}
+	res := r.db.Model(ut).Find(
+		ut,
+		"token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?",
+		tokenHash, purpose, time.Now().Unix(),
+	)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return ut, nil
+}
+
+func (r *gormRepository) ConsumeUserToken(ut *models.UserToken) error {
+	now := time.Now().Unix()
+	ut.ConsumedAt = &now
+	return r.db.Save(ut).Error
+}
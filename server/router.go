@@ -1,34 +1,74 @@
 package server
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
 
+	"rua.plus/gymo/config"
 	"rua.plus/gymo/controllers"
 	"rua.plus/gymo/db"
+	"rua.plus/gymo/mailer"
 	"rua.plus/gymo/middlewares"
+	contactsrepo "rua.plus/gymo/repository/contacts"
+	userrepo "rua.plus/gymo/repository/user"
+	contactsservice "rua.plus/gymo/service/contacts"
+	userservice "rua.plus/gymo/service/user"
+	"rua.plus/gymo/ws"
 )
 
-func InitRouter() *gin.Engine {
+func InitRouter(cfg *config.Config) *gin.Engine {
+	gin.SetMode(cfg.Server.Mode)
 	router := gin.New()
 
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middlewares.RateLimit(cfg))
 
 	v1 := router.Group("/v1")
 
 	root := controllers.RootController{}
 	v1.GET("/", root.Root)
 
+	database, err := db.Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("server: open database: %v", err)
+	}
+
+	hub := ws.NewHub(database)
+	go hub.Run()
+
+	userRepo := userrepo.New(database)
+	contactsRepo := contactsrepo.New(database)
+
 	user := controllers.User{
-		Db: db.Db,
+		Service: userservice.New(userRepo, mailer.New(cfg.Mailer), cfg.App.BaseURL, cfg.JWT),
+	}
+	contacts := controllers.Contacts{
+		Service: contactsservice.New(contactsRepo, userRepo, hub),
 	}
-	v1.GET("/user/", user.GetUser)      // query single user by query
-	v1.POST("/register/", user.AddUser) // register account
-	v1.POST("/login/", user.Login)      // login
-	v1.Use(middlewares.TokenAuth())
-	v1.Use(middlewares.TokenTimeAuth(db.Db))
-	v1.PATCH("/user/", user.ModifyUser) // modify user infomation
-	v1.POST("/user/", user.UserSelf)    // get current logged in user infomation
+	v1.GET("/user/", user.GetUser)                    // query single user by query
+	v1.POST("/register/", user.AddUser)               // register account
+	v1.POST("/login/", user.Login)                    // login
+	v1.POST("/refresh/", user.Refresh)                // exchange a refresh token for a new access token
+	v1.GET("/verify/", user.VerifyEmail)              // consume an email verification token
+	v1.POST("/password/forgot/", user.ForgotPassword) // request a password reset email
+	v1.POST("/password/reset/", user.ResetPassword)   // consume a password reset token
+	v1.Use(middlewares.TokenAuth(cfg.JWT, database)) // signature + expiry only; sessions are revoked per refresh token
+	v1.PATCH("/user/", user.ModifyUser)              // modify user infomation
+	v1.POST("/user/", user.UserSelf)                 // get current logged in user infomation
+	v1.POST("/logout/", user.Logout)                 // revoke the current refresh token
+	v1.POST("/logout/all/", user.LogoutAll)          // revoke every session for the user
+
+	v1.GET("/ws", func(c *gin.Context) { ws.ServeWs(hub, c) }) // upgrade to a notification socket
+
+	v1.POST("/contacts/make/", contacts.MakeFirend)                    // send a firend request
+	v1.GET("/contacts/requests/incoming/", contacts.IncomingRequests)  // requests sent to the current user
+	v1.GET("/contacts/requests/outgoing/", contacts.OutgoingRequests)  // requests sent by the current user
+	v1.POST("/contacts/requests/:id/accept/", contacts.AcceptRequest) // accept a pending request
+	v1.POST("/contacts/requests/:id/reject/", contacts.RejectRequest) // reject a pending request
+	v1.DELETE("/contacts/requests/:id/", contacts.CancelRequest)      // sender cancels a pending request
+	v1.GET("/contacts/", contacts.ListContacts)                       // list the current user's contacts
 
 	return router
 }
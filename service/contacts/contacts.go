@@ -0,0 +1,159 @@
+// Package contacts 承载好友关系相关的业务规则
+// controllers 只负责 HTTP 适配，不应再直接持有 *gorm.DB
+package contacts
+
+import (
+	"errors"
+	"net/http"
+
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+	contactsrepo "rua.plus/gymo/repository/contacts"
+	userrepo "rua.plus/gymo/repository/user"
+	"rua.plus/gymo/utils"
+)
+
+var (
+	errSelfRequest  = errors.New("service/contacts: cannot make firend with self")
+	errNotRecipient = errors.New("service/contacts: not the recipient of this request")
+	errNotSender    = errors.New("service/contacts: not the sender of this request")
+)
+
+// Notifier 向在线用户推送实时事件，具体实现见 ws 包的 Hub
+type Notifier interface {
+	Push(uid uint, event string, payload any)
+}
+
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+type Service interface {
+	MakeFirend(fromUID, toUID uint) (*models.FirendRequest, error)
+	Accept(requestID, uid uint) (*models.FirendRequest, error)
+	Reject(requestID, uid uint) (*models.FirendRequest, error)
+	Cancel(requestID, uid uint) (*models.FirendRequest, error)
+	ListIncoming(uid uint, page Page) ([]models.FirendRequest, error)
+	ListOutgoing(uid uint, page Page) ([]models.FirendRequest, error)
+	ListContacts(uid uint, page Page) ([]models.User, error)
+}
+
+type service struct {
+	contacts contactsrepo.Repository
+	users    userrepo.Repository
+	notifier Notifier
+}
+
+func New(contacts contactsrepo.Repository, users userrepo.Repository, notifier Notifier) Service {
+	return &service{contacts: contacts, users: users, notifier: notifier}
+}
+
+func (s *service) notify(uid uint, event string, payload any) {
+	if s.notifier != nil {
+		s.notifier.Push(uid, event, payload)
+	}
+}
+
+func (s *service) MakeFirend(fromUID, toUID uint) (*models.FirendRequest, error) {
+	if fromUID == toUID {
+		return nil, utils.WithStatus(http.StatusUnprocessableEntity, errSelfRequest)
+	}
+
+	if _, err := s.users.FindByUID(toUID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.contacts.FindContact(fromUID, toUID); err == nil {
+		return nil, repository.ErrConflict
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.contacts.FindRequestBetween(fromUID, toUID); err == nil {
+		return nil, repository.ErrAlreadyExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	// 对方已经先发过一条请求：直接促成好友关系，而不是再建一条独立的请求，
+	// 否则两条请求互不关联，其中一条被接受后另一条会一直悬着
+	if reverse, err := s.contacts.FindRequestBetween(toUID, fromUID); err == nil {
+		if err := s.contacts.Accept(reverse); err != nil {
+			return nil, err
+		}
+		s.notify(reverse.FromUserUID, "friend_accepted", reverse)
+		return reverse, nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	req := &models.FirendRequest{FromUserUID: fromUID, ToUserUID: toUID}
+	if err := s.contacts.CreateRequest(req); err != nil {
+		return nil, err
+	}
+
+	s.notify(toUID, "friend_request", req)
+	return req, nil
+}
+
+func (s *service) Accept(requestID, uid uint) (*models.FirendRequest, error) {
+	req, err := s.contacts.FindRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ToUserUID != uid {
+		return nil, utils.WithStatus(http.StatusForbidden, errNotRecipient)
+	}
+
+	if err := s.contacts.Accept(req); err != nil {
+		return nil, err
+	}
+
+	s.notify(req.FromUserUID, "friend_accepted", req)
+	return req, nil
+}
+
+func (s *service) Reject(requestID, uid uint) (*models.FirendRequest, error) {
+	req, err := s.contacts.FindRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ToUserUID != uid {
+		return nil, utils.WithStatus(http.StatusForbidden, errNotRecipient)
+	}
+
+	if err := s.contacts.DeleteRequest(req); err != nil {
+		return nil, err
+	}
+
+	s.notify(req.FromUserUID, "friend_rejected", req)
+	return req, nil
+}
+
+func (s *service) Cancel(requestID, uid uint) (*models.FirendRequest, error) {
+	req, err := s.contacts.FindRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromUserUID != uid {
+		return nil, utils.WithStatus(http.StatusForbidden, errNotSender)
+	}
+
+	if err := s.contacts.DeleteRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *service) ListIncoming(uid uint, page Page) ([]models.FirendRequest, error) {
+	return s.contacts.ListIncoming(uid, page.Offset, page.Limit)
+}
+
+func (s *service) ListOutgoing(uid uint, page Page) ([]models.FirendRequest, error) {
+	return s.contacts.ListOutgoing(uid, page.Offset, page.Limit)
+}
+
+func (s *service) ListContacts(uid uint, page Page) ([]models.User, error) {
+	return s.contacts.ListContacts(uid, page.Offset, page.Limit)
+}
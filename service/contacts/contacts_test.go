@@ -0,0 +1,178 @@
+package contacts
+
+import (
+	"errors"
+	"testing"
+
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+)
+
+// fakeUserRepo only implements enough of userrepo.Repository for MakeFirend to
+// look up the recipient.
+type fakeUserRepo struct {
+	byUID map[uint]*models.User
+}
+
+func (r fakeUserRepo) FindByEmail(email string) (*models.User, error) { return nil, repository.ErrNotFound }
+
+func (r fakeUserRepo) FindByUID(uid uint) (*models.User, error) {
+	if u, ok := r.byUID[uid]; ok {
+		return u, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r fakeUserRepo) Create(u *models.User) error { return nil }
+func (r fakeUserRepo) Save(u *models.User) error   { return nil }
+func (r fakeUserRepo) Delete(u *models.User) error { return nil }
+
+func (r fakeUserRepo) CreateRefreshToken(rt *models.RefreshToken) error { return nil }
+func (r fakeUserRepo) FindActiveRefreshToken(tokenHash string, notBefore int64) (*models.RefreshToken, error) {
+	return nil, repository.ErrNotFound
+}
+func (r fakeUserRepo) SaveRefreshToken(rt *models.RefreshToken) error { return nil }
+func (r fakeUserRepo) RevokeAllRefreshTokens(uid uint) error          { return nil }
+
+func (r fakeUserRepo) CreateUserToken(ut *models.UserToken) error { return nil }
+func (r fakeUserRepo) FindActiveUserToken(tokenHash, purpose string) (*models.UserToken, error) {
+	return nil, repository.ErrNotFound
+}
+func (r fakeUserRepo) ConsumeUserToken(ut *models.UserToken) error { return nil }
+
+type fakeContactsRepo struct {
+	requests map[uint]*models.FirendRequest
+	contacts map[[2]uint]*models.Contact
+	nextID   uint
+}
+
+func newFakeContactsRepo() *fakeContactsRepo {
+	return &fakeContactsRepo{
+		requests: map[uint]*models.FirendRequest{},
+		contacts: map[[2]uint]*models.Contact{},
+	}
+}
+
+func (r *fakeContactsRepo) FindRequestBetween(fromUID, toUID uint) (*models.FirendRequest, error) {
+	for _, req := range r.requests {
+		if req.FromUserUID == fromUID && req.ToUserUID == toUID {
+			return req, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeContactsRepo) FindRequestByID(id uint) (*models.FirendRequest, error) {
+	if req, ok := r.requests[id]; ok {
+		return req, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeContactsRepo) CreateRequest(req *models.FirendRequest) error {
+	r.nextID++
+	req.ID = r.nextID
+	r.requests[req.ID] = req
+	return nil
+}
+
+func (r *fakeContactsRepo) DeleteRequest(req *models.FirendRequest) error {
+	delete(r.requests, req.ID)
+	return nil
+}
+
+func (r *fakeContactsRepo) ListIncoming(uid uint, offset, limit int) ([]models.FirendRequest, error) {
+	return nil, nil
+}
+
+func (r *fakeContactsRepo) ListOutgoing(uid uint, offset, limit int) ([]models.FirendRequest, error) {
+	return nil, nil
+}
+
+func (r *fakeContactsRepo) FindContact(userUID, firendUID uint) (*models.Contact, error) {
+	if c, ok := r.contacts[[2]uint{userUID, firendUID}]; ok {
+		return c, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeContactsRepo) ListContacts(uid uint, offset, limit int) ([]models.User, error) {
+	return nil, nil
+}
+
+func (r *fakeContactsRepo) Accept(req *models.FirendRequest) error {
+	r.contacts[[2]uint{req.FromUserUID, req.ToUserUID}] = &models.Contact{UserUID: req.FromUserUID, Firend: req.ToUserUID}
+	r.contacts[[2]uint{req.ToUserUID, req.FromUserUID}] = &models.Contact{UserUID: req.ToUserUID, Firend: req.FromUserUID}
+	delete(r.requests, req.ID)
+	return nil
+}
+
+type fakeNotifier struct {
+	pushed []uint
+}
+
+func (n *fakeNotifier) Push(uid uint, event string, payload any) {
+	n.pushed = append(n.pushed, uid)
+}
+
+func TestMakeFirend(t *testing.T) {
+	users := fakeUserRepo{byUID: map[uint]*models.User{2: {UID: 2}}}
+	contacts := newFakeContactsRepo()
+	notifier := &fakeNotifier{}
+	svc := New(contacts, users, notifier)
+
+	if _, err := svc.MakeFirend(1, 1); err == nil {
+		t.Fatal("expected an error for a self request")
+	}
+
+	contacts.contacts[[2]uint{1, 2}] = &models.Contact{UserUID: 1, Firend: 2}
+	if _, err := svc.MakeFirend(1, 2); !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("expected ErrConflict for an existing contact, got %v", err)
+	}
+	delete(contacts.contacts, [2]uint{1, 2})
+
+	contacts.requests[99] = &models.FirendRequest{ID: 99, FromUserUID: 1, ToUserUID: 2}
+	if _, err := svc.MakeFirend(1, 2); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists for a duplicate pending request, got %v", err)
+	}
+	delete(contacts.requests, 99)
+
+	if _, err := svc.MakeFirend(1, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notifier.pushed) != 1 || notifier.pushed[0] != 2 {
+		t.Fatalf("expected the recipient to be notified, got %v", notifier.pushed)
+	}
+}
+
+// TestMakeFirend_MutualRequestAutoAccepts makes sure that if B already asked A,
+// A asking B back resolves into a friendship instead of a second, unrelated request.
+func TestMakeFirend_MutualRequestAutoAccepts(t *testing.T) {
+	users := fakeUserRepo{byUID: map[uint]*models.User{1: {UID: 1}, 2: {UID: 2}}}
+	contacts := newFakeContactsRepo()
+	notifier := &fakeNotifier{}
+	svc := New(contacts, users, notifier)
+
+	contacts.requests[1] = &models.FirendRequest{ID: 1, FromUserUID: 2, ToUserUID: 1}
+
+	req, err := svc.MakeFirend(1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.FromUserUID != 2 || req.ToUserUID != 1 {
+		t.Fatalf("expected the original request to be returned, got %+v", req)
+	}
+
+	if len(contacts.requests) != 0 {
+		t.Fatalf("expected the original request to be consumed, got %v", contacts.requests)
+	}
+	if _, err := contacts.FindContact(1, 2); err != nil {
+		t.Fatalf("expected 1 and 2 to be contacts: %v", err)
+	}
+	if _, err := contacts.FindContact(2, 1); err != nil {
+		t.Fatalf("expected 2 and 1 to be contacts: %v", err)
+	}
+	if len(notifier.pushed) != 1 || notifier.pushed[0] != 2 {
+		t.Fatalf("expected the original sender to be notified of the acceptance, got %v", notifier.pushed)
+	}
+}
@@ -0,0 +1,287 @@
+// Package user 承载用户与会话相关的业务规则和事务边界
+// controllers 只负责 HTTP 适配，不应再直接持有 *gorm.DB
+package user
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"rua.plus/gymo/config"
+	"rua.plus/gymo/mailer"
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+	userrepo "rua.plus/gymo/repository/user"
+	"rua.plus/gymo/utils"
+)
+
+const (
+	verifyEmailTokenTTL   = 24 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+var errPasswordIncorrect = errors.New("service/user: password not correct")
+
+// Session 是一次登录或刷新返回的凭证对
+type Session struct {
+	User         *models.User
+	AccessToken  string
+	RefreshToken string
+}
+
+type Service interface {
+	GetByEmail(email string) (*models.User, error)
+	Register(u *models.User) error
+	Modify(uid uint, patch Patch) (*models.User, error)
+	Delete(u *models.User) error
+
+	Login(email, password, device string) (*Session, error)
+	Refresh(refreshToken string) (*Session, error)
+	Logout(refreshToken string) error
+	LogoutAll(uid uint) error
+
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+// Patch 携带 ModifyUser 允许更新的字段，零值表示“不修改”
+type Patch struct {
+	Username string
+	Email    string
+	Password string
+}
+
+type service struct {
+	users   userrepo.Repository
+	mailer  mailer.Mailer
+	baseURL string
+	jwt     config.JWT
+}
+
+func New(users userrepo.Repository, m mailer.Mailer, baseURL string, jwtCfg config.JWT) Service {
+	return &service{users: users, mailer: m, baseURL: baseURL, jwt: jwtCfg}
+}
+
+func (s *service) GetByEmail(email string) (*models.User, error) {
+	return s.users.FindByEmail(email)
+}
+
+// Register 创建账号后会立即发出邮箱验证邮件；邮件发送失败不影响注册结果，只记录日志，
+// 本服务不提供重新发送验证邮件的方法
+func (s *service) Register(u *models.User) error {
+	if err := s.users.Create(u); err != nil {
+		return err
+	}
+
+	if err := s.sendUserToken(u, models.UserTokenPurposeVerifyEmail, verifyEmailTokenTTL,
+		"Verify your gymo account", "/v1/verify/?token=%s", "Click to verify your account: %s"); err != nil {
+		log.Println("service/user: send verification email:", err)
+	}
+
+	return nil
+}
+
+// sendUserToken 生成一个一次性令牌、存入 user_tokens 表并通过邮件发给用户
+func (s *service) sendUserToken(u *models.User, purpose string, ttl time.Duration, subject, linkPath, bodyFormat string) error {
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ut := &models.UserToken{
+		UserUID:   u.UID,
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	}
+	if err := s.users.CreateUserToken(ut); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf(s.baseURL+linkPath, token)
+	return s.mailer.Send(u.Email, subject, fmt.Sprintf(bodyFormat, link))
+}
+
+func (s *service) Modify(uid uint, patch Patch) (*models.User, error) {
+	u, err := s.users.FindByUID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.Username != "" {
+		u.Username = patch.Username
+	}
+	if patch.Email != "" {
+		u.Email = patch.Email
+	}
+	if patch.Password != "" {
+		u.Password = patch.Password
+		u.HashPassword()
+	}
+
+	if err := s.users.Save(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *service) Delete(u *models.User) error {
+	return s.users.Delete(u)
+}
+
+func (s *service) Login(email, password, device string) (*Session, error) {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.CheckPasswordHash(password, u.Password); err != nil {
+		return nil, utils.WithStatus(http.StatusUnauthorized, errPasswordIncorrect)
+	}
+
+	session, err := s.issueSession(u, device)
+	if err != nil {
+		return nil, err
+	}
+
+	u.LastLogin = time.Now().Unix()
+	if err := s.users.Save(u); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *service) issueSession(u *models.User, device string) (*Session, error) {
+	accessToken, err := utils.GenerateToken(int(u.ID), s.jwt.AccessSecret, s.jwt.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, tokenHash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	rt := &models.RefreshToken{
+		UserUID:    u.UID,
+		TokenHash:  tokenHash,
+		Device:     device,
+		IssuedAt:   now,
+		LastUsedAt: now,
+		Revoked:    false,
+	}
+	if err := s.users.CreateRefreshToken(rt); err != nil {
+		return nil, err
+	}
+
+	return &Session{User: u, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// activeRefreshToken 查找一个未吊销且未过期的 refresh token，过期的判定依据是配置的 RefreshTTL
+func (s *service) activeRefreshToken(plain string) (*models.RefreshToken, error) {
+	notBefore := time.Now().Add(-s.jwt.RefreshTTL).Unix()
+	return s.users.FindActiveRefreshToken(utils.HashToken(plain), notBefore)
+}
+
+func (s *service) Refresh(refreshToken string) (*Session, error) {
+	rt, err := s.activeRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.users.FindByUID(rt.UserUID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateToken(int(u.ID), s.jwt.AccessSecret, s.jwt.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	rt.LastUsedAt = time.Now().Unix()
+	if err := s.users.SaveRefreshToken(rt); err != nil {
+		return nil, err
+	}
+
+	return &Session{User: u, AccessToken: accessToken}, nil
+}
+
+func (s *service) Logout(refreshToken string) error {
+	rt, err := s.activeRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	rt.Revoked = true
+	return s.users.SaveRefreshToken(rt)
+}
+
+func (s *service) LogoutAll(uid uint) error {
+	return s.users.RevokeAllRefreshTokens(uid)
+}
+
+// VerifyEmail 消费一个邮箱验证令牌并把账号标记为已验证
+func (s *service) VerifyEmail(token string) error {
+	ut, err := s.users.FindActiveUserToken(utils.HashToken(token), models.UserTokenPurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	u, err := s.users.FindByUID(ut.UserUID)
+	if err != nil {
+		return err
+	}
+
+	u.EmailVerified = true
+	if err := s.users.Save(u); err != nil {
+		return err
+	}
+
+	return s.users.ConsumeUserToken(ut)
+}
+
+// ForgotPassword 在邮箱不存在时同样返回 nil（对应 HTTP 200），邮件发送失败也只记录日志，
+// 这样调用方无法通过响应差异枚举出已注册的邮箱；只有查询用户本身出错时才返回错误
+func (s *service) ForgotPassword(email string) error {
+	u, err := s.users.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := s.sendUserToken(u, models.UserTokenPurposeResetPassword, resetPasswordTokenTTL,
+		"Reset your gymo password", "/v1/password/reset/?token=%s", "Click to reset your password: %s"); err != nil {
+		log.Println("service/user: send password reset email:", err)
+	}
+
+	return nil
+}
+
+// ResetPassword 消费一个密码重置令牌并写入新密码
+func (s *service) ResetPassword(token, newPassword string) error {
+	ut, err := s.users.FindActiveUserToken(utils.HashToken(token), models.UserTokenPurposeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	u, err := s.users.FindByUID(ut.UserUID)
+	if err != nil {
+		return err
+	}
+
+	u.Password = newPassword
+	u.HashPassword()
+	if err := s.users.Save(u); err != nil {
+		return err
+	}
+
+	return s.users.ConsumeUserToken(ut)
+}
@@ -0,0 +1,151 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"rua.plus/gymo/config"
+	"rua.plus/gymo/models"
+	"rua.plus/gymo/repository"
+)
+
+// fakeUserRepo is an in-memory stand-in for userrepo.Repository so these tests
+// exercise the service's rules without a database.
+type fakeUserRepo struct {
+	byEmail       map[string]*models.User
+	byUID         map[uint]*models.User
+	refreshTokens []*models.RefreshToken
+}
+
+func newFakeUserRepo() *fakeUserRepo {
+	return &fakeUserRepo{byEmail: map[string]*models.User{}, byUID: map[uint]*models.User{}}
+}
+
+func (r *fakeUserRepo) FindByEmail(email string) (*models.User, error) {
+	if u, ok := r.byEmail[email]; ok {
+		return u, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepo) FindByUID(uid uint) (*models.User, error) {
+	if u, ok := r.byUID[uid]; ok {
+		return u, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepo) Create(u *models.User) error {
+	r.byEmail[u.Email] = u
+	r.byUID[u.UID] = u
+	return nil
+}
+
+func (r *fakeUserRepo) Save(u *models.User) error {
+	r.byEmail[u.Email] = u
+	r.byUID[u.UID] = u
+	return nil
+}
+
+func (r *fakeUserRepo) Delete(u *models.User) error {
+	delete(r.byEmail, u.Email)
+	delete(r.byUID, u.UID)
+	return nil
+}
+
+func (r *fakeUserRepo) CreateRefreshToken(rt *models.RefreshToken) error {
+	r.refreshTokens = append(r.refreshTokens, rt)
+	return nil
+}
+
+func (r *fakeUserRepo) FindActiveRefreshToken(tokenHash string, notBefore int64) (*models.RefreshToken, error) {
+	for _, rt := range r.refreshTokens {
+		if rt.TokenHash == tokenHash && !rt.Revoked && rt.IssuedAt > notBefore {
+			return rt, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepo) SaveRefreshToken(rt *models.RefreshToken) error { return nil }
+
+func (r *fakeUserRepo) RevokeAllRefreshTokens(uid uint) error {
+	for _, rt := range r.refreshTokens {
+		if rt.UserUID == uid {
+			rt.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) CreateUserToken(ut *models.UserToken) error { return nil }
+
+func (r *fakeUserRepo) FindActiveUserToken(tokenHash, purpose string) (*models.UserToken, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeUserRepo) ConsumeUserToken(ut *models.UserToken) error { return nil }
+
+type fakeMailer struct{}
+
+func (fakeMailer) Send(to, subject, body string) error { return nil }
+
+func newTestService(users *fakeUserRepo) Service {
+	return New(users, fakeMailer{}, "http://localhost", config.JWT{
+		AccessSecret: "test-secret",
+		AccessTTL:    time.Minute,
+		RefreshTTL:   time.Hour,
+	})
+}
+
+func TestLogin(t *testing.T) {
+	u := &models.User{UID: 1, Email: "a@example.com", Password: "hunter2x1"}
+	u.HashPassword()
+
+	users := newFakeUserRepo()
+	users.byEmail[u.Email] = u
+	users.byUID[u.UID] = u
+	svc := newTestService(users)
+
+	if _, err := svc.Login(u.Email, "wrong-password", "device-a"); err == nil {
+		t.Fatal("expected an error for the wrong password")
+	}
+
+	session, err := svc.Login(u.Email, "hunter2x1", "device-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.AccessToken == "" || session.RefreshToken == "" {
+		t.Fatal("expected both an access and a refresh token to be issued")
+	}
+	if len(users.refreshTokens) != 1 {
+		t.Fatalf("expected one refresh token to be persisted, got %d", len(users.refreshTokens))
+	}
+}
+
+// TestRefresh_ExpiredToken makes sure a refresh token older than RefreshTTL is
+// rejected instead of staying valid forever.
+func TestRefresh_ExpiredToken(t *testing.T) {
+	u := &models.User{UID: 1, Email: "a@example.com", Password: "hunter2x1"}
+	u.HashPassword()
+
+	users := newFakeUserRepo()
+	users.byEmail[u.Email] = u
+	users.byUID[u.UID] = u
+	svc := newTestService(users)
+
+	session, err := svc.Login(u.Email, "hunter2x1", "device-a")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if _, err := svc.Refresh(session.RefreshToken); err != nil {
+		t.Fatalf("expected a fresh refresh token to still be valid: %v", err)
+	}
+
+	users.refreshTokens[0].IssuedAt -= int64(2 * time.Hour / time.Second)
+
+	if _, err := svc.Refresh(session.RefreshToken); err == nil {
+		t.Fatal("expected a refresh token older than RefreshTTL to be rejected")
+	}
+}
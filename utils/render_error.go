@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"rua.plus/gymo/repository"
+)
+
+// CodedError 让 service 层可以显式指定某个领域错误应当呈现为哪个 HTTP 状态码，
+// 这样 repository/service 本身不需要知道 net/http 的任何细节
+type CodedError struct {
+	Status int
+	Err    error
+}
+
+func (e *CodedError) Error() string { return e.Err.Error() }
+func (e *CodedError) Unwrap() error  { return e.Err }
+
+// WithStatus 包装一个错误并附上它应该对应的 HTTP 状态码
+func WithStatus(status int, err error) error {
+	return &CodedError{Status: status, Err: err}
+}
+
+// RenderError 是 controllers 把 service/repository 返回的领域错误翻译成 HTTP 响应的统一出口，
+// 取代过去每个 handler 里重复的 `err.Error()` + 手选状态码
+func RenderError(c *gin.Context, resp *BasicRes, err error) {
+	status := http.StatusInternalServerError
+
+	var coded *CodedError
+	switch {
+	case errors.As(err, &coded):
+		status = coded.Status
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, repository.ErrAlreadyExists), errors.Is(err, repository.ErrConflict):
+		status = http.StatusConflict
+	}
+
+	FailedAndReturn(c, resp, status, err.Error())
+}
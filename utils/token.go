@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AccessClaims 是 access token 携带的声明：除了标准的签发/过期时间，
+// 只额外带一个 uid，用来在 middlewares.TokenAuth 里定位对应的用户
+type AccessClaims struct {
+	UID int `json:"uid"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken 签发一个 access token，使用 HS256 对 AccessClaims 签名，
+// ttl 过后 middlewares.TokenAuth 会拒绝它——见 chunk0-3 对 TokenAuth 的重写
+func GenerateToken(uid int, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := AccessClaims{
+		UID: uid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
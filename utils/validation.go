@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationError 把 validator.ValidationErrors 整理成一个按字段名索引的错误信息 map，
+// 这样前端可以直接拿 field 名去渲染对应的输入框，而不必解析英文句子
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed"
+}
+
+// newValidationError 把每个字段失败的 tag 翻译成一句简短的提示
+func newValidationError(verrs validator.ValidationErrors) *ValidationError {
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		fields[fe.Field()] = validationMessage(fe)
+	}
+	return &ValidationError{Fields: fields}
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "password":
+		return "must be at least 8 characters and include both letters and digits"
+	case "username":
+		return "may only contain letters, digits and underscores"
+	default:
+		return "is invalid"
+	}
+}
+
+// validationResponse 是 VALIDATION 错误返回给客户端的结构
+type validationResponse struct {
+	Status string            `json:"status"`
+	Code   string            `json:"code"`
+	Fields map[string]string `json:"fields"`
+}
+
+// RenderBindError 统一处理 ShouldBindWith 的返回值：
+// 结构体校验失败时输出按字段归类的 VALIDATION 错误，其它绑定失败（例如请求体不是合法
+// JSON）则保持原来的纯文本错误
+func RenderBindError(c *gin.Context, resp *BasicRes, err error) {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		c.JSON(http.StatusUnprocessableEntity, validationResponse{
+			Status: "error",
+			Code:   "VALIDATION",
+			Fields: newValidationError(verrs).Fields,
+		})
+		return
+	}
+
+	FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,125 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"rua.plus/gymo/utils"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 4096
+	sendBufferSize = 16
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// 前端与服务端不同源部署是常态，这里不做来源校验，任何 Origin 都会被接受
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Client 代表一个在线用户的 websocket 连接
+// 每个连接由一个读 goroutine 和一个写 goroutine 驱动，二者只通过 send 通道交互，
+// 这样慢客户端不会阻塞 Hub 对其它用户的推送
+type Client struct {
+	hub  *Hub
+	uid  uint
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// ServeWs 将当前请求升级为 websocket 连接并注册到 hub
+// 需要放在 TokenAuth 之后，以便从 context 中取出当前登录用户
+func ServeWs(hub *Hub, c *gin.Context) {
+	resp := &utils.BasicRes{}
+	u := utils.GetContextUser(c, resp)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		utils.FailedAndReturn(c, resp, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	client := &Client{
+		hub:  hub,
+		uid:  u.UID,
+		conn: conn,
+		send: make(chan []byte, sendBufferSize),
+	}
+	hub.register <- client
+
+	go client.writePump()
+	go client.readPump()
+}
+
+func (client *Client) deliver(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case client.send <- body:
+		return nil
+	default:
+		return errors.New("ws: send buffer full")
+	}
+}
+
+// readPump 只负责读取并丢弃客户端消息、维持 pong 心跳；任何错误都会注销该连接
+func (client *Client) readPump() {
+	defer func() {
+		client.hub.unregister <- client
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(maxMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump 把 send 通道里的消息写给客户端，并定期发送 ping 保活
+func (client *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				client.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
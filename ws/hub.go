@@ -0,0 +1,130 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"rua.plus/gymo/models"
+)
+
+// Event 是通过 websocket 推送给客户端的消息
+type Event struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
+}
+
+// Hub 维护所有在线连接，按 UID 索引
+// 对于不在线的用户，事件会被持久化到 `notifications` 表，在其下次连接时补发
+type Hub struct {
+	Db *gorm.DB
+
+	mu      sync.RWMutex
+	clients map[uint]*Client
+
+	register   chan *Client
+	unregister chan *Client
+	push       chan pushRequest
+}
+
+// pushRequest 是一次 Push 调用在进入 Run 的 actor 循环前排队等待处理的请求
+type pushRequest struct {
+	uid     uint
+	event   string
+	payload any
+}
+
+func NewHub(db *gorm.DB) *Hub {
+	return &Hub{
+		Db:         db,
+		clients:    make(map[uint]*Client),
+		register:   make(chan *Client),
+		unregister: make(chan *Client),
+		push:       make(chan pushRequest),
+	}
+}
+
+// Run 应当在单独的 goroutine 中运行，负责串行化对 clients 表的增删以及向它们的投递。
+// register/unregister/push 都只在这一个 goroutine 里处理，这样 close(client.send)
+// 和向 client.send 的写入不会来自不同的 goroutine，避免在客户端刚好断线时向已关闭的
+// channel 发送而 panic
+func (h *Hub) Run() {
+	for {
+		select {
+		case client := <-h.register:
+			h.mu.Lock()
+			h.clients[client.uid] = client
+			h.mu.Unlock()
+			h.flushPending(client)
+		case client := <-h.unregister:
+			h.mu.Lock()
+			if existing, ok := h.clients[client.uid]; ok && existing == client {
+				delete(h.clients, client.uid)
+				close(client.send)
+			}
+			h.mu.Unlock()
+		case req := <-h.push:
+			h.dispatch(req.uid, req.event, req.payload)
+		}
+	}
+}
+
+// Push 实现 service/contacts 的 Notifier 接口：把投递请求交给 Run 的 actor 循环处理，
+// 调用方所在的请求 goroutine 不会直接碰 clients 或任何 client 的 send channel
+func (h *Hub) Push(uid uint, event string, payload any) {
+	h.push <- pushRequest{uid: uid, event: event, payload: payload}
+}
+
+// dispatch 只应当从 Run 所在的 goroutine 调用：在线则直接投递，离线则落库等待下次连接再补发
+func (h *Hub) dispatch(uid uint, event string, payload any) {
+	h.mu.RLock()
+	client, online := h.clients[uid]
+	h.mu.RUnlock()
+
+	if online {
+		if err := client.deliver(Event{Type: event, Payload: payload}); err == nil {
+			return
+		}
+	}
+
+	h.persist(uid, event, payload)
+}
+
+func (h *Hub) persist(uid uint, event string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("ws: marshal notification payload:", err)
+		return
+	}
+
+	notification := &models.Notification{
+		UID:     uid,
+		Event:   event,
+		Payload: string(body),
+	}
+	if err := h.Db.Create(notification).Error; err != nil {
+		log.Println("ws: persist notification:", err)
+	}
+}
+
+// flushPending 在用户上线后，把积压的离线通知一次性发给它并标记为已读
+func (h *Hub) flushPending(client *Client) {
+	var pending []models.Notification
+	if err := h.Db.Where("uid = ? AND delivered_at IS NULL", client.uid).Find(&pending).Error; err != nil {
+		log.Println("ws: load pending notifications:", err)
+		return
+	}
+
+	for _, n := range pending {
+		var payload any
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			payload = n.Payload
+		}
+		if err := client.deliver(Event{Type: n.Event, Payload: payload}); err != nil {
+			break
+		}
+		h.Db.Model(&n).Update("delivered_at", gorm.Expr("NOW()"))
+	}
+}